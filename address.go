@@ -0,0 +1,67 @@
+package main
+
+import "sort"
+
+// addressScope categorizes a discovered address by how reachable it is,
+// so results can be ordered by usefulness and, later, reported alongside
+// the address itself.
+type addressScope int
+
+const (
+	scopeUnknown addressScope = iota
+	scopeLoopback
+	scopeLinkLocal
+	scopePrivate // RFC 1918 for IPv4, ULA (fc00::/7) for IPv6.
+	scopePublic  // Global unicast that isn't private.
+)
+
+// String renders the scope the way it should appear in logs and JSON
+// output.
+func (s addressScope) String() string {
+	switch s {
+	case scopeLoopback:
+		return "loopback"
+	case scopeLinkLocal:
+		return "link-local"
+	case scopePrivate:
+		return "private"
+	case scopePublic:
+		return "public"
+	default:
+		return "unknown"
+	}
+}
+
+// discoveredAddress is one address found on one interface, tagged with
+// enough information to filter, sort, and (eventually) serialize it.
+type discoveredAddress struct {
+	iface  string
+	value  string
+	family string // "ipv4" or "ipv6"
+	scope  addressScope
+	// source identifies where this address came from: "interface" for
+	// everything getIps() finds locally, or a PublicIPResolver backend
+	// name ("stun", "https-echo", "dns") for addresses resolved from
+	// outside the host's own view of itself.
+	source string
+}
+
+// sortAddresses orders addresses IPv4 before IPv6, and within each family
+// public scope before private before link-local, so the "best" address
+// to hand a human or another machine comes first.
+func sortAddresses(addresses []discoveredAddress) {
+	scopeRank := map[addressScope]int{
+		scopePublic:    0,
+		scopePrivate:   1,
+		scopeLinkLocal: 2,
+		scopeLoopback:  3,
+		scopeUnknown:   4,
+	}
+
+	sort.SliceStable(addresses, func(i, j int) bool {
+		if addresses[i].family != addresses[j].family {
+			return addresses[i].family == "ipv4"
+		}
+		return scopeRank[addresses[i].scope] < scopeRank[addresses[j].scope]
+	})
+}
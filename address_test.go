@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestSortAddresses(t *testing.T) {
+	addresses := []discoveredAddress{
+		{iface: "eth0", value: "fe80::1", family: "ipv6", scope: scopeLinkLocal},
+		{iface: "eth0", value: "203.0.113.5", family: "ipv4", scope: scopePublic},
+		{iface: "eth0", value: "2001:db8::1", family: "ipv6", scope: scopePublic},
+		{iface: "eth0", value: "192.168.1.5", family: "ipv4", scope: scopePrivate},
+	}
+
+	sortAddresses(addresses)
+
+	want := []string{"203.0.113.5", "192.168.1.5", "2001:db8::1", "fe80::1"}
+	for i, value := range want {
+		if addresses[i].value != value {
+			t.Fatalf("position %d: got %q, want %q (order: %v)", i, addresses[i].value, value, addresses)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net"
+	"strings"
+)
+
+// Name prefixes of virtual interfaces created by container/bridge tooling
+// that are never useful as a host's own address, so they're excluded by
+// default regardless of their up/running state.
+var defaultExcludePrefixes = []string{
+	"docker",
+	"br-",
+	"veth",
+	"virbr",
+	"cni",
+	"flannel",
+}
+
+// Name prefixes recognized as VPN/tunnel interfaces. These are skipped by
+// default (their addresses usually aren't what "what's my IP" wants), but
+// can be pulled back in with --include-vpn.
+var vpnPrefixes = []string{
+	"wg",
+	"ts",
+	"tailscale",
+	"utun",
+	"tun",
+	"tap",
+}
+
+// InterfaceFilter decides which network interfaces getIps() should look
+// at, beyond the basic loopback/point-to-point/up checks net.Interfaces()
+// itself doesn't express.
+type InterfaceFilter struct {
+	// excludePrefixes are name prefixes that are always rejected, unless
+	// the interface is named explicitly in includeNames.
+	excludePrefixes []string
+	// includeNames and excludeNames are exact interface names from
+	// --include-iface / --exclude-iface. They take priority over every
+	// other rule below.
+	includeNames map[string]bool
+	excludeNames map[string]bool
+	// includeVPN, when false (the default), rejects interfaces recognized
+	// as VPN/tunnel interfaces (see vpnPrefixes).
+	includeVPN bool
+}
+
+// newInterfaceFilter builds an InterfaceFilter from the comma-separated
+// --include-iface / --exclude-iface values and the --include-vpn flag.
+func newInterfaceFilter(includeIface, excludeIface string, includeVPN bool) *InterfaceFilter {
+	return &InterfaceFilter{
+		excludePrefixes: defaultExcludePrefixes,
+		includeNames:    namesToSet(includeIface),
+		excludeNames:    namesToSet(excludeIface),
+		includeVPN:      includeVPN,
+	}
+}
+
+// namesToSet turns a comma-separated list of interface names into a
+// lookup set, ignoring empty entries.
+func namesToSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	if list == "" {
+		return set
+	}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// isVPNInterface reports whether name matches one of the recognized
+// VPN/tunnel prefixes (WireGuard, Tailscale, generic tun/tap).
+func isVPNInterface(name string) bool {
+	for _, prefix := range vpnPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether iface should be considered a candidate for
+// address extraction.
+func (f *InterfaceFilter) Allowed(iface net.Interface) bool {
+	if f.excludeNames[iface.Name] {
+		return false
+	}
+	if f.includeNames[iface.Name] {
+		return true
+	}
+
+	if iface.Flags&net.FlagLoopback != 0 {
+		return false
+	}
+	// An interface can be administratively UP while its link is actually
+	// down (cable unplugged, peer unreachable); FlagRunning catches that,
+	// which plain FlagUp doesn't.
+	if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagRunning == 0 {
+		return false
+	}
+
+	if isVPNInterface(iface.Name) {
+		return f.includeVPN
+	}
+
+	// We should also ignore Point-to-Point addresses, because they
+	// should not be used on production server/VM. VPN interfaces are
+	// handled above, before this check, since many of them are
+	// point-to-point too.
+	if iface.Flags&net.FlagPointToPoint != 0 {
+		return false
+	}
+	// Interfaces without a hardware address and that aren't a recognized
+	// VPN/tunnel kind are almost always something synthetic we don't
+	// want (e.g. a dummy or placeholder interface).
+	if len(iface.HardwareAddr) == 0 {
+		return false
+	}
+
+	for _, prefix := range f.excludePrefixes {
+		if strings.HasPrefix(iface.Name, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
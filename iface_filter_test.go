@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func testHWAddr() net.HardwareAddr {
+	return net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01}
+}
+
+func TestInterfaceFilterAllowed(t *testing.T) {
+	upRunning := net.FlagUp | net.FlagRunning
+
+	tests := []struct {
+		name   string
+		iface  net.Interface
+		filter *InterfaceFilter
+		want   bool
+	}{
+		{
+			name:   "plain up interface with a hardware address is allowed",
+			iface:  net.Interface{Name: "eth0", Flags: upRunning, HardwareAddr: testHWAddr()},
+			filter: newInterfaceFilter("", "", false),
+			want:   true,
+		},
+		{
+			name:   "loopback is rejected",
+			iface:  net.Interface{Name: "lo", Flags: upRunning | net.FlagLoopback, HardwareAddr: testHWAddr()},
+			filter: newInterfaceFilter("", "", false),
+			want:   false,
+		},
+		{
+			name:   "administratively up but not running is rejected",
+			iface:  net.Interface{Name: "eth1", Flags: net.FlagUp, HardwareAddr: testHWAddr()},
+			filter: newInterfaceFilter("", "", false),
+			want:   false,
+		},
+		{
+			name:   "exact substring match no longer triggers the old brwan0 false positive",
+			iface:  net.Interface{Name: "brwan0", Flags: upRunning, HardwareAddr: testHWAddr()},
+			filter: newInterfaceFilter("", "", false),
+			want:   true,
+		},
+		{
+			name:   "docker bridge prefix is rejected",
+			iface:  net.Interface{Name: "docker0", Flags: upRunning, HardwareAddr: testHWAddr()},
+			filter: newInterfaceFilter("", "", false),
+			want:   false,
+		},
+		{
+			name:   "br- prefix is rejected",
+			iface:  net.Interface{Name: "br-123abc", Flags: upRunning, HardwareAddr: testHWAddr()},
+			filter: newInterfaceFilter("", "", false),
+			want:   false,
+		},
+		{
+			name:   "wireguard interface is rejected by default",
+			iface:  net.Interface{Name: "wg0", Flags: upRunning | net.FlagPointToPoint},
+			filter: newInterfaceFilter("", "", false),
+			want:   false,
+		},
+		{
+			name:   "wireguard interface is allowed with --include-vpn",
+			iface:  net.Interface{Name: "wg0", Flags: upRunning | net.FlagPointToPoint},
+			filter: newInterfaceFilter("", "", true),
+			want:   true,
+		},
+		{
+			name:   "tailscale interface is allowed with --include-vpn",
+			iface:  net.Interface{Name: "tailscale0", Flags: upRunning | net.FlagPointToPoint},
+			filter: newInterfaceFilter("", "", true),
+			want:   true,
+		},
+		{
+			name:   "non-VPN point-to-point interface is rejected",
+			iface:  net.Interface{Name: "ppp0", Flags: upRunning | net.FlagPointToPoint, HardwareAddr: testHWAddr()},
+			filter: newInterfaceFilter("", "", true),
+			want:   false,
+		},
+		{
+			name:   "--exclude-iface wins over an otherwise-allowed interface",
+			iface:  net.Interface{Name: "eth0", Flags: upRunning, HardwareAddr: testHWAddr()},
+			filter: newInterfaceFilter("", "eth0", false),
+			want:   false,
+		},
+		{
+			name:   "--include-iface overrides every other rule, even for a bridge",
+			iface:  net.Interface{Name: "docker0", Flags: 0},
+			filter: newInterfaceFilter("docker0", "", false),
+			want:   true,
+		},
+		{
+			name:   "interface without a hardware address and not a VPN is rejected",
+			iface:  net.Interface{Name: "dummy0", Flags: upRunning},
+			filter: newInterfaceFilter("", "", false),
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Allowed(tt.iface); got != tt.want {
+				t.Errorf("Allowed(%+v) = %v, want %v", tt.iface, got, tt.want)
+			}
+		})
+	}
+}
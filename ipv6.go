@@ -0,0 +1,97 @@
+package main
+
+import (
+	"log"
+	"net"
+	"strings"
+)
+
+var (
+	// v6ULARange is the actual Unique Local Address range (RFC 4193).
+	// fd00::/8 is merely the "locally assigned" half of it; fc00::/7 is
+	// the whole thing.
+	v6ULARange = ipCIDR{cidr: "fc00::/7"}
+	// v6TailscaleCGNAT is the CGNAT-equivalent range Tailscale assigns
+	// its own addresses from. It's routable only within a tailnet, so it
+	// should never be offered up as "the" address for this host.
+	v6TailscaleCGNAT = ipCIDR{cidr: "fd7a:115c:a1e0::/48"}
+	// v6DiscardPrefix (RFC 6666) is never a real destination.
+	v6DiscardPrefix = ipCIDR{cidr: "100::/64"}
+)
+
+// checksForIPv6 decides whether address is usable and, if so, which
+// scope it belongs to. Unlike the IPv4 side, link-local addresses are
+// considered usable here: on an IPv6 LAN they're often the only address
+// two hosts share, as long as the zone index travels with them (see
+// splitV6Zone).
+func checksForIPv6(address net.IP) (bool, addressScope) {
+	log.Printf("Checking IPv6 address '%s' for usableness...", address.String())
+
+	if address.IsMulticast() || address.IsLinkLocalMulticast() || address.IsInterfaceLocalMulticast() {
+		log.Printf("Can't use Multicast IPv6 address! Removing '%s' from list of usable addresses", address.String())
+		return false, scopeUnknown
+	}
+	if address.IsLoopback() {
+		log.Printf("Can't use loopback address! Removing '%s' from list of usable addresses", address.String())
+		return false, scopeLoopback
+	}
+
+	tailscaleCIDR := mustParseCIDR(v6TailscaleCGNAT.cidr)
+	if tailscaleCIDR.Contains(address) {
+		log.Printf("Address '%s' is in Tailscale's CGNAT range, not a real destination. Removing.", address.String())
+		return false, scopeUnknown
+	}
+	discardCIDR := mustParseCIDR(v6DiscardPrefix.cidr)
+	if discardCIDR.Contains(address) {
+		log.Printf("Address '%s' is in the IPv6 discard prefix. Removing.", address.String())
+		return false, scopeUnknown
+	}
+
+	if address.IsLinkLocalUnicast() {
+		log.Printf("Address '%s' is link-local, keeping it (zone index required to use it).", address.String())
+		return true, scopeLinkLocal
+	}
+
+	ulaCIDR := mustParseCIDR(v6ULARange.cidr)
+	if ulaCIDR.Contains(address) {
+		log.Printf("Address '%s' is a Unique Local Address.", address.String())
+		return true, scopePrivate
+	}
+
+	if address.IsGlobalUnicast() {
+		log.Printf("Address '%s' is a Global Unicast Address.", address.String())
+		return true, scopePublic
+	}
+
+	return false, scopeUnknown
+}
+
+// mustParseCIDR parses one of our own hard-coded CIDR constants. A parse
+// failure here is a programming error, not something callers can act on.
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		log.Fatalf("Invalid hard-coded CIDR '%s': %s", cidr, err.Error())
+	}
+	return network
+}
+
+// splitV6Zone pulls the zone index (the part after '%') out of an
+// address string before it's handed to net.ParseCIDR, which doesn't
+// understand zones. It returns the de-zoned address/prefix string and
+// the zone, which is empty for anything that isn't link-local.
+func splitV6Zone(addr string) (string, string) {
+	percent := strings.IndexByte(addr, '%')
+	if percent == -1 {
+		return addr, ""
+	}
+
+	rest := addr[percent:]
+	slash := strings.IndexByte(rest, '/')
+	if slash == -1 {
+		return addr[:percent], addr[percent+1:]
+	}
+
+	zone := rest[1:slash]
+	return addr[:percent] + rest[slash:], zone
+}
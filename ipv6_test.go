@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestChecksForIPv6(t *testing.T) {
+	tests := []struct {
+		name      string
+		address   string
+		wantOK    bool
+		wantScope addressScope
+	}{
+		{"loopback is rejected", "::1", false, scopeLoopback},
+		{"multicast is rejected", "ff02::1", false, scopeUnknown},
+		{"link-local is usable", "fe80::1", true, scopeLinkLocal},
+		{"ULA (fc00::/7 low half) is private", "fc00::1", true, scopePrivate},
+		{"ULA (fd00::/8, the old fd00-only range) is private", "fd12:3456:789a::1", true, scopePrivate},
+		{"global unicast is public", "2001:db8::1", true, scopePublic},
+		{"Tailscale CGNAT range is rejected despite being inside fd00::/8", "fd7a:115c:a1e0::1", false, scopeUnknown},
+		{"IPv6 discard prefix is rejected", "100::1", false, scopeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, scope := checksForIPv6(net.ParseIP(tt.address))
+			if ok != tt.wantOK || scope != tt.wantScope {
+				t.Errorf("checksForIPv6(%s) = (%v, %v), want (%v, %v)", tt.address, ok, scope, tt.wantOK, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestSplitV6Zone(t *testing.T) {
+	tests := []struct {
+		name       string
+		addr       string
+		wantDezone string
+		wantZone   string
+	}{
+		{"no zone, no prefix", "2001:db8::1", "2001:db8::1", ""},
+		{"no zone, with prefix", "2001:db8::1/64", "2001:db8::1/64", ""},
+		{"zone, no prefix", "fe80::1%eth0", "fe80::1", "eth0"},
+		{"zone, with prefix", "fe80::1%eth0/64", "fe80::1/64", "eth0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dezoned, zone := splitV6Zone(tt.addr)
+			if dezoned != tt.wantDezone || zone != tt.wantZone {
+				t.Errorf("splitV6Zone(%s) = (%q, %q), want (%q, %q)", tt.addr, dezoned, zone, tt.wantDezone, tt.wantZone)
+			}
+		})
+	}
+}
@@ -1,13 +1,11 @@
 package main
 
 import (
-	"os"
+	"context"
+	"flag"
 	// stdlib
 	"log"
 	"net"
-	"os/exec"
-	"path/filepath"
-	"strings"
 )
 
 var (
@@ -17,17 +15,6 @@ var (
 		ipCIDR{cidr: "172.16.0.0/12"},
 		ipCIDR{cidr: "192.168.0.0/16"},
 	}
-	v6PrivateRanges = []ipCIDR{
-		ipCIDR{cidr: "fd00::/8"},
-	}
-	// Terminals we can use for showing logs and errors.
-	terminals = []string{
-		"gnome-terminal",
-		"konsole",
-		"urxvt",
-		"xfce4-terminal",
-		"xterm",
-	}
 )
 
 // Structure that holds IP CIRD.
@@ -36,75 +23,79 @@ type ipCIDR struct {
 }
 
 func main() {
-	addresses, errors := getIps()
+	notifierName := flag.String("notifier", "", "Notifier to use (zenity, kdialog, notify-send, systray, macos, windows-toast, stdout). Autodetected if empty.")
+	includeIface := flag.String("include-iface", "", "Comma-separated interface names to always include, bypassing every other filter.")
+	excludeIface := flag.String("exclude-iface", "", "Comma-separated interface names to always exclude.")
+	includeVPN := flag.Bool("include-vpn", false, "Also consider VPN/tunnel interfaces (WireGuard, Tailscale, tun/tap).")
+	serveAddr := flag.String("serve", "", "Instead of showing a notification, serve discovered addresses over HTTP/JSON at this address (e.g. ':8080').")
+	watch := flag.Bool("watch", false, "Keep running and re-display addresses whenever the interface/address set changes.")
+	watchInterval := flag.Duration("watch-interval", defaultPollInterval, "Polling interval for --watch when native change notifications aren't available.")
+	noPublic := flag.Bool("no-public", false, "Don't try to resolve this host's public IP via STUN/HTTPS/DNS.")
+	flag.Parse()
+
+	filter := newInterfaceFilter(*includeIface, *excludeIface, *includeVPN)
+
+	addresses, errors := getIps(filter)
 	if len(errors) > 0 {
 		log.Print(errors)
 	}
-	showIps(addresses)
-}
 
-// IPv4 address checks.
-func checksForIPv4(address net.IP) bool {
-	log.Printf("Checking IPv4 address '%s' for usableness...", address.String())
-
-	// Next checks are performed only for logging purposes!
-	// We do not need multicast addresses (if suddenly).
-	if address.IsMulticast() || address.IsLinkLocalMulticast() || address.IsInterfaceLocalMulticast() {
-		log.Printf("Can't use Multicast IPv4 address! Removing '%s'  from list of usable addresses", address.String())
-		return false
-	}
-	// If loopback address suddenly appears here - we should not use it.
-	if address.IsLoopback() {
-		log.Printf("Can't use loopback address! Removing '%s' from list of usable addresses", address.String())
-		return false
-	}
-	// We should not use link-local addresses.
-	if address.IsLinkLocalUnicast() {
-		log.Printf("Can't use link-local addresses! Removing '%s' from list of usable addresses", address.String())
-		return false
+	// --watch re-runs getIps() itself on every change and has nowhere to
+	// put a one-shot public IP lookup, so resolving it here would just be
+	// wasted latency (and network calls) for a result nobody sees.
+	if !*noPublic && !*watch {
+		ip, source, err := resolvePublicIP(context.Background(), defaultPublicIPResolvers())
+		if err != nil {
+			log.Print(err.Error())
+		} else {
+			addresses = append(addresses, discoveredAddress{
+				value:  ip.String(),
+				family: publicIPFamily(ip),
+				scope:  scopePublic,
+				source: source,
+			})
+			sortAddresses(addresses)
+		}
 	}
 
-	// Global Unicast addresses contains private ranges, so we should check
-	// ranges here.
-	if address.IsGlobalUnicast() {
-		local_address := false
-		// Check if address within private addresses ranges.
-		for _, network := range v4PrivateRanges {
-			_, cidr, _ := net.ParseCIDR(network.cidr)
-			if cidr.Contains(address) {
-				local_address = true
-			}
+	if *serveAddr != "" {
+		if err := serve(*serveAddr, addresses); err != nil {
+			log.Fatal(err)
 		}
+		return
+	}
 
-		if local_address {
-			log.Printf("Address '%s' looks good.", address.String())
-			return true
-		}
+	notifier, err := selectNotifier(*notifierName)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	// All other things - FALSE.
-	return false
+	if *watch {
+		runWatch(filter, notifier, *watchInterval)
+		return
+	}
+	showIps(addressValues(addresses), notifier)
 }
 
-// IPv6 address checks.
-func checksForIPv6(address net.IP) bool {
-	log.Printf("Checking IPv6 address '%s' for usableness...", address.String())
+// IPv4 address checks.
+func checksForIPv4(address net.IP) (bool, addressScope) {
+	log.Printf("Checking IPv4 address '%s' for usableness...", address.String())
 
 	// Next checks are performed only for logging purposes!
 	// We do not need multicast addresses (if suddenly).
 	if address.IsMulticast() || address.IsLinkLocalMulticast() || address.IsInterfaceLocalMulticast() {
-		log.Printf("Can't use Multicast IPv4 address! Removing '%s' from list of usable addresses", address.String())
-		return false
+		log.Printf("Can't use Multicast IPv4 address! Removing '%s'  from list of usable addresses", address.String())
+		return false, scopeUnknown
 	}
 	// If loopback address suddenly appears here - we should not use it.
 	if address.IsLoopback() {
 		log.Printf("Can't use loopback address! Removing '%s' from list of usable addresses", address.String())
-		return false
+		return false, scopeLoopback
 	}
 	// We should not use link-local addresses.
 	if address.IsLinkLocalUnicast() {
 		log.Printf("Can't use link-local addresses! Removing '%s' from list of usable addresses", address.String())
-		return false
+		return false, scopeLinkLocal
 	}
 
 	// Global Unicast addresses contains private ranges, so we should check
@@ -112,7 +103,7 @@ func checksForIPv6(address net.IP) bool {
 	if address.IsGlobalUnicast() {
 		local_address := false
 		// Check if address within private addresses ranges.
-		for _, network := range v6PrivateRanges {
+		for _, network := range v4PrivateRanges {
 			_, cidr, _ := net.ParseCIDR(network.cidr)
 			if cidr.Contains(address) {
 				local_address = true
@@ -121,18 +112,21 @@ func checksForIPv6(address net.IP) bool {
 
 		if local_address {
 			log.Printf("Address '%s' looks good.", address.String())
-			return true
+			return true, scopePrivate
 		}
+
+		log.Printf("Address '%s' looks good.", address.String())
+		return true, scopePublic
 	}
 
 	// All other things - FALSE.
-	return false
+	return false, scopeUnknown
 }
 
-func getIps() ([]string, []error) {
+func getIps(filter *InterfaceFilter) ([]discoveredAddress, []error) {
 	log.Print("Getting all available IP addresses...")
 
-	var addresses []string
+	var addresses []discoveredAddress
 	var errors []error
 
 	interfacesRaw, err := net.Interfaces()
@@ -143,21 +137,7 @@ func getIps() ([]string, []error) {
 	// Check interfaces for usabillness.
 	var usableIfaces []string
 	for i := range interfacesRaw {
-		// Ignore unneeded addresses.
-		if interfacesRaw[i].Flags&net.FlagLoopback != 0 {
-			continue
-		}
-		// We should also ignore Point-to-Point addresses, because they
-		// should not be used on production server/VM.
-		if interfacesRaw[i].Flags&net.FlagPointToPoint != 0 {
-			continue
-		}
-		// Also we should skip interfaces that didn't have "UP" state.
-		if interfacesRaw[i].Flags&net.FlagUp == 0 {
-			continue
-		}
-		// Bridges? IGNORE!
-		if strings.Contains(interfacesRaw[i].Name, "br") {
+		if !filter.Allowed(interfacesRaw[i]) {
 			continue
 		}
 
@@ -184,81 +164,57 @@ func getIps() ([]string, []error) {
 
 		// Check for addresses usabillness.
 		for ii := range addressesRaw {
-			addressRaw, _, err3 := net.ParseCIDR(addressesRaw[ii].String())
+			// Zone indices (the "%eth0" in "fe80::1%eth0/64") aren't
+			// understood by net.ParseCIDR, so they have to be split off
+			// first and reattached afterwards.
+			dezoned, zone := splitV6Zone(addressesRaw[ii].String())
+			addressRaw, _, err3 := net.ParseCIDR(dezoned)
 			if err3 != nil {
 				errors = append(errors, err3)
 				continue
 			}
-			var usable = false
+
+			var usable bool
+			var scope addressScope
+			var family string
 			if addressRaw.To4() != nil {
-				usable = checksForIPv4(addressRaw)
+				family = "ipv4"
+				usable, scope = checksForIPv4(addressRaw)
 			} else {
-				usable = checksForIPv6(addressRaw)
+				family = "ipv6"
+				usable, scope = checksForIPv6(addressRaw)
 			}
 
-			if usable {
-				addresses = append(addresses, addressRaw.String())
-			}
-		}
-	}
-
-	return addresses, errors
-}
-
-func showIps(ips []string) {
-	ipsAsString := strings.Join(ips, ",")
-	log.Print("IPs string: " + ipsAsString)
-
-	// Find zenity.
-	var paths []string
-	path, pathFound := os.LookupEnv("PATH")
-	if !pathFound {
-		log.Print("PATH variable isn't defined or empty. Looking in default locations.")
-		paths = []string{"/bin", "/usr/bin", "/usr/local/bin"}
-	} else {
-		paths = strings.Split(path, ":")
-	}
-
-	log.Print("Looking for apps in these paths:")
-	log.Print(paths)
-
-	var zenity string
-	var terminal string
-	for i := range paths {
-		if zenity == "" {
-			zenityTempPath := filepath.Join(paths[i], "zenity")
-			if _, err := os.Stat(zenityTempPath); err == nil {
-				zenity = zenityTempPath
+			if !usable {
+				continue
 			}
-		}
 
-		if terminal == "" {
-			for ii := range terminals {
-				terminalTempPath := filepath.Join(paths[i], terminals[ii])
-				if _, err := os.Stat(terminalTempPath); err == nil {
-					terminal = terminalTempPath
-				}
+			value := addressRaw.String()
+			if zone != "" {
+				value = value + "%" + zone
 			}
-		}
 
-		if terminal != "" && zenity != "" {
-			break
+			addresses = append(addresses, discoveredAddress{
+				iface:  usableIfaces[i],
+				value:  value,
+				family: family,
+				scope:  scope,
+				source: "interface",
+			})
 		}
 	}
 
-	if zenity == "" {
-		log.Fatal("Failed to find Zenity binary!")
-	}
-
-	log.Print("Will use:")
-	log.Printf("\tTerminal at '%s'", terminal)
-	log.Printf("\tZenity at '%s'", zenity)
+	sortAddresses(addresses)
 
-	ipsText := "Your IP addresses: " + ipsAsString
+	return addresses, errors
+}
 
-	zenityCmd := exec.Command(zenity, "--info", "--title=IPShow", "--text="+ipsText, "--no-wrap")
-	err := zenityCmd.Run()
-	if err != nil {
-		log.Print(err.Error())
+// addressValues extracts the plain address strings, in order, for
+// notifiers that just want something to print.
+func addressValues(addresses []discoveredAddress) []string {
+	values := make([]string, len(addresses))
+	for i := range addresses {
+		values[i] = addresses[i].value
 	}
+	return values
 }
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Notifier is implemented by every backend capable of surfacing the
+// discovered IP addresses to the user. Exactly one notifier is used per
+// run, chosen either explicitly via --notifier or by probing Available()
+// in priority order.
+type Notifier interface {
+	// Name returns the lowercase identifier used for --notifier matching
+	// and logging.
+	Name() string
+	// Available reports whether this notifier can be used on the current
+	// system (binary present, platform matches, etc).
+	Available() bool
+	// Notify displays the given IP addresses to the user.
+	Notify(ips []string) error
+}
+
+// autoNotifiers lists the backends considered when --notifier isn't
+// given, in priority order. SystrayNotifier is deliberately left out of
+// this list: systray.Run() blocks until the user quits from the tray
+// menu, so auto-selecting it on any desktop session would turn a
+// one-shot "show my IP and exit" tool into one that hangs forever. It's
+// only reachable via explicit `--notifier systray`.
+var autoNotifiers = []Notifier{
+	&ZenityNotifier{},
+	&KDialogNotifier{},
+	&NotifySendNotifier{},
+	&MacOSNotifier{},
+	&WindowsToastNotifier{},
+	&StdoutNotifier{},
+}
+
+// allNotifiers additionally includes backends that --notifier can name
+// explicitly but that auto-detection won't pick on its own.
+var allNotifiers = append(append([]Notifier{}, autoNotifiers...), &SystrayNotifier{})
+
+// selectNotifier returns the notifier to use. If name is non-empty it must
+// match a known, available notifier exactly; otherwise the first available
+// notifier in priority order is used, falling back to StdoutNotifier.
+func selectNotifier(name string) (Notifier, error) {
+	if name != "" {
+		for _, n := range allNotifiers {
+			if n.Name() != name {
+				continue
+			}
+			if !n.Available() {
+				return nil, fmt.Errorf("notifier '%s' isn't available on this system", name)
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("unknown notifier '%s'", name)
+	}
+
+	for _, n := range autoNotifiers {
+		if n.Available() {
+			return n, nil
+		}
+	}
+
+	// StdoutNotifier is always available, so we should never get here.
+	return &StdoutNotifier{}, nil
+}
+
+// findBinary looks up name in every directory of PATH (falling back to a
+// handful of common locations when PATH isn't set) and returns the first
+// match, or an empty string if nothing was found.
+func findBinary(name string) string {
+	var paths []string
+	path, pathFound := os.LookupEnv("PATH")
+	if !pathFound {
+		paths = []string{"/bin", "/usr/bin", "/usr/local/bin"}
+	} else {
+		paths = strings.Split(path, string(os.PathListSeparator))
+	}
+
+	for i := range paths {
+		candidate := filepath.Join(paths[i], name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// ZenityNotifier shows a GTK dialog via zenity. This is the original,
+// Linux/X11-oriented behaviour of ipshow.
+type ZenityNotifier struct{}
+
+func (n *ZenityNotifier) Name() string { return "zenity" }
+
+func (n *ZenityNotifier) Available() bool { return findBinary("zenity") != "" }
+
+func (n *ZenityNotifier) Notify(ips []string) error {
+	zenity := findBinary("zenity")
+	ipsText := "Your IP addresses: " + strings.Join(ips, ",")
+	cmd := exec.Command(zenity, "--info", "--title=IPShow", "--text="+ipsText, "--no-wrap")
+	return cmd.Run()
+}
+
+// KDialogNotifier shows a KDE dialog via kdialog, for Plasma desktops
+// where zenity may be missing or feel out of place.
+type KDialogNotifier struct{}
+
+func (n *KDialogNotifier) Name() string { return "kdialog" }
+
+func (n *KDialogNotifier) Available() bool { return findBinary("kdialog") != "" }
+
+func (n *KDialogNotifier) Notify(ips []string) error {
+	kdialog := findBinary("kdialog")
+	ipsText := "Your IP addresses: " + strings.Join(ips, ",")
+	cmd := exec.Command(kdialog, "--title", "IPShow", "--msgbox", ipsText)
+	return cmd.Run()
+}
+
+// NotifySendNotifier posts a libnotify desktop notification via
+// notify-send. Unlike the dialog-based notifiers this doesn't require a
+// working display server dialog stack, which makes it more reliable on
+// Wayland.
+type NotifySendNotifier struct{}
+
+func (n *NotifySendNotifier) Name() string { return "notify-send" }
+
+func (n *NotifySendNotifier) Available() bool { return findBinary("notify-send") != "" }
+
+func (n *NotifySendNotifier) Notify(ips []string) error {
+	notifySend := findBinary("notify-send")
+	ipsText := "Your IP addresses: " + strings.Join(ips, ",")
+	cmd := exec.Command(notifySend, "IPShow", ipsText)
+	return cmd.Run()
+}
+
+// MacOSNotifier shows a native notification on macOS via osascript.
+type MacOSNotifier struct{}
+
+func (n *MacOSNotifier) Name() string { return "macos" }
+
+func (n *MacOSNotifier) Available() bool {
+	return runtime.GOOS == "darwin" && findBinary("osascript") != ""
+}
+
+func (n *MacOSNotifier) Notify(ips []string) error {
+	osascript := findBinary("osascript")
+	ipsText := "Your IP addresses: " + strings.Join(ips, ",")
+	script := fmt.Sprintf("display notification %q with title %q", ipsText, "IPShow")
+	cmd := exec.Command(osascript, "-e", script)
+	return cmd.Run()
+}
+
+// WindowsToastNotifier shows a Windows toast notification by driving
+// PowerShell, which avoids pulling in a cgo/Windows-Runtime dependency
+// for something this small.
+type WindowsToastNotifier struct{}
+
+func (n *WindowsToastNotifier) Name() string { return "windows-toast" }
+
+func (n *WindowsToastNotifier) Available() bool {
+	return runtime.GOOS == "windows" && findBinary("powershell.exe") != ""
+}
+
+func (n *WindowsToastNotifier) Notify(ips []string) error {
+	powershell := findBinary("powershell.exe")
+	ipsText := "Your IP addresses: " + strings.Join(ips, ",")
+	script := fmt.Sprintf(
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null; "+
+			"Add-Type -AssemblyName System.Windows.Forms; "+
+			"$notify = New-Object System.Windows.Forms.NotifyIcon; "+
+			"$notify.Icon = [System.Drawing.SystemIcons]::Information; "+
+			"$notify.Visible = $true; "+
+			"$notify.ShowBalloonTip(5000, 'IPShow', %q, [System.Windows.Forms.ToolTipIcon]::Info)",
+		ipsText,
+	)
+	cmd := exec.Command(powershell, "-NoProfile", "-Command", script)
+	return cmd.Run()
+}
+
+// StdoutNotifier simply prints the addresses to standard output. It is
+// always available and is the last resort on headless boxes where none
+// of the desktop-oriented notifiers apply.
+type StdoutNotifier struct{}
+
+func (n *StdoutNotifier) Name() string { return "stdout" }
+
+func (n *StdoutNotifier) Available() bool { return true }
+
+func (n *StdoutNotifier) Notify(ips []string) error {
+	for i := range ips {
+		fmt.Println(ips[i])
+	}
+	return nil
+}
+
+// showIps displays the given IP addresses using notifier, logging and
+// falling back to StdoutNotifier if the chosen backend fails.
+func showIps(ips []string, notifier Notifier) {
+	log.Printf("Showing IPs using '%s' notifier: %s", notifier.Name(), strings.Join(ips, ","))
+
+	if err := notifier.Notify(ips); err != nil {
+		log.Print(err.Error())
+		if notifier.Name() != "stdout" {
+			log.Print("Falling back to stdout.")
+			(&StdoutNotifier{}).Notify(ips)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+//go:build systray
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/getlantern/systray"
+)
+
+// SystrayNotifier parks a system tray icon whose tooltip and menu show
+// the discovered addresses, for users who'd rather not have a dialog pop
+// up and steal focus. It blocks until the user quits from the tray menu.
+//
+// This requires cgo and, on Linux, GTK3/libayatana-appindicator dev
+// packages, so it's only built in when requested with `-tags systray`
+// (see notifier_systray_stub.go for the default, dependency-free build).
+type SystrayNotifier struct{}
+
+func (n *SystrayNotifier) Name() string { return "systray" }
+
+func (n *SystrayNotifier) Available() bool {
+	// Systray needs a desktop session; headless boxes should fall through
+	// to something else.
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != "" || runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+}
+
+func (n *SystrayNotifier) Notify(ips []string) error {
+	ipsText := strings.Join(ips, ", ")
+	systray.Run(func() {
+		systray.SetTitle("IPShow")
+		systray.SetTooltip("Your IP addresses: " + ipsText)
+		item := systray.AddMenuItem(ipsText, "Your IP addresses")
+		quit := systray.AddMenuItem("Quit", "Close ipshow")
+		go func() {
+			select {
+			case <-item.ClickedCh:
+			case <-quit.ClickedCh:
+				systray.Quit()
+			}
+		}()
+	}, func() {})
+	return nil
+}
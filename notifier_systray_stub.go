@@ -0,0 +1,19 @@
+//go:build !systray
+
+package main
+
+import "fmt"
+
+// SystrayNotifier is a stub used when ipshow is built without the
+// `systray` build tag, so the default build doesn't need cgo or a
+// desktop's GTK/appindicator headers. See notifier_systray.go for the
+// real implementation.
+type SystrayNotifier struct{}
+
+func (n *SystrayNotifier) Name() string { return "systray" }
+
+func (n *SystrayNotifier) Available() bool { return false }
+
+func (n *SystrayNotifier) Notify(ips []string) error {
+	return fmt.Errorf("ipshow was built without systray support (rebuild with -tags systray)")
+}
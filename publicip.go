@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pion/stun"
+)
+
+// publicIPBackendTimeout bounds how long any single PublicIPResolver
+// backend gets before it's considered failed.
+const publicIPBackendTimeout = 3 * time.Second
+
+// PublicIPResolver is a backend capable of asking some external party
+// what address this host is seen as.
+type PublicIPResolver interface {
+	// Name identifies the backend in the "source" field of results, e.g.
+	// "stun", "https-echo", "dns".
+	Name() string
+	// Resolve returns the public IP this backend saw, or an error if it
+	// couldn't be determined within ctx's deadline.
+	Resolve(ctx context.Context) (net.IP, error)
+}
+
+// defaultPublicIPResolvers returns the resolvers ipshow tries by
+// default: a pool of STUN servers, a few HTTPS echo services, and the
+// DNS-based tricks Google and OpenDNS expose.
+func defaultPublicIPResolvers() []PublicIPResolver {
+	return []PublicIPResolver{
+		&stunResolver{servers: []string{
+			"stun.l.google.com:19302",
+			"stun1.l.google.com:19302",
+			"stun.cloudflare.com:3478",
+		}},
+		&httpsEchoResolver{url: "https://ifconfig.co/ip"},
+		&httpsEchoResolver{url: "https://api.ipify.org"},
+		&httpsEchoResolver{url: "https://icanhazip.com"},
+		googleDNSResolver(),
+		openDNSResolver(),
+	}
+}
+
+// publicIPFamily reports "ipv4" or "ipv6" for an address returned by a
+// PublicIPResolver.
+func publicIPFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
+
+// resolvePublicIP races every resolver and returns the first one to
+// succeed, so a single slow or blocked backend (common with STUN behind
+// restrictive firewalls) doesn't hold up the others.
+func resolvePublicIP(ctx context.Context, resolvers []PublicIPResolver) (net.IP, string, error) {
+	type result struct {
+		ip     net.IP
+		source string
+		err    error
+	}
+
+	results := make(chan result, len(resolvers))
+	for _, r := range resolvers {
+		go func(r PublicIPResolver) {
+			rctx, cancel := context.WithTimeout(ctx, publicIPBackendTimeout)
+			defer cancel()
+			ip, err := r.Resolve(rctx)
+			results <- result{ip: ip, source: r.Name(), err: err}
+		}(r)
+	}
+
+	var firstErr error
+	for range resolvers {
+		r := <-results
+		if r.err == nil && r.ip != nil {
+			return r.ip, r.source, nil
+		}
+		if firstErr == nil && r.err != nil {
+			firstErr = r.err
+		}
+	}
+
+	return nil, "", fmt.Errorf("all public IP resolvers failed, last error: %w", firstErr)
+}
+
+// stunResolver asks a STUN server (RFC 5389) for our server-reflexive
+// address, i.e. the address the NAT in front of us maps our traffic to.
+type stunResolver struct {
+	servers []string
+}
+
+func (r *stunResolver) Name() string { return "stun" }
+
+func (r *stunResolver) Resolve(ctx context.Context) (net.IP, error) {
+	var lastErr error
+	for _, server := range r.servers {
+		ip, err := stunQuery(ctx, server)
+		if err == nil {
+			return ip, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func stunQuery(ctx context.Context, server string) (net.IP, error) {
+	conn, err := net.Dial("udp4", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client, err := stun.NewClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	message := stun.MustBuild(stun.TransactionID, stun.BindingRequest)
+
+	var ip net.IP
+	var resolveErr error
+	done := make(chan struct{})
+
+	err = client.Do(message, func(event stun.Event) {
+		defer close(done)
+		if event.Error != nil {
+			resolveErr = event.Error
+			return
+		}
+		var xorAddr stun.XORMappedAddress
+		if err := xorAddr.GetFrom(event.Message); err != nil {
+			resolveErr = err
+			return
+		}
+		ip = xorAddr.IP
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-done:
+		return ip, resolveErr
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// httpsEchoResolver asks an HTTP(S) "what's my IP" endpoint that returns
+// the caller's address as a bare string body.
+type httpsEchoResolver struct {
+	url string
+}
+
+func (r *httpsEchoResolver) Name() string { return "https-echo" }
+
+func (r *httpsEchoResolver) Resolve(ctx context.Context) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return nil, fmt.Errorf("%s didn't return a parseable IP", r.url)
+	}
+	return ip, nil
+}
+
+// dnsResolver looks up our public address via a DNS trick hosted by a
+// specific resolver, rather than an HTTP endpoint.
+type dnsResolver struct {
+	name         string
+	resolverAddr string
+	query        func(ctx context.Context, resolver *net.Resolver) (net.IP, error)
+}
+
+func (r *dnsResolver) Name() string { return r.name }
+
+func (r *dnsResolver) Resolve(ctx context.Context) (net.IP, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, r.resolverAddr)
+		},
+	}
+	return r.query(ctx, resolver)
+}
+
+// googleDNSResolver queries "o-o.myaddr.l.google.com TXT" via Google's
+// public resolver, which answers with our address as seen by Google.
+func googleDNSResolver() *dnsResolver {
+	return &dnsResolver{
+		name:         "dns",
+		resolverAddr: "ns1.google.com:53",
+		query: func(ctx context.Context, resolver *net.Resolver) (net.IP, error) {
+			txts, err := resolver.LookupTXT(ctx, "o-o.myaddr.l.google.com")
+			if err != nil {
+				return nil, err
+			}
+			for _, txt := range txts {
+				if ip := net.ParseIP(strings.Trim(txt, `"`)); ip != nil {
+					return ip, nil
+				}
+			}
+			return nil, fmt.Errorf("no parseable IP in Google DNS TXT response")
+		},
+	}
+}
+
+// openDNSResolver queries "myip.opendns.com A" via OpenDNS's resolver,
+// which answers with our address as seen by OpenDNS.
+func openDNSResolver() *dnsResolver {
+	return &dnsResolver{
+		name:         "dns",
+		resolverAddr: "resolver1.opendns.com:53",
+		query: func(ctx context.Context, resolver *net.Resolver) (net.IP, error) {
+			ips, err := resolver.LookupIP(ctx, "ip4", "myip.opendns.com")
+			if err != nil {
+				return nil, err
+			}
+			if len(ips) == 0 {
+				return nil, fmt.Errorf("no A records in OpenDNS response")
+			}
+			return ips[0], nil
+		},
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/mdns"
+)
+
+// mdnsServiceName is the service type ipshow announces itself as, so
+// other machines on the LAN can find it without knowing its address
+// upfront.
+const mdnsServiceName = "_ipshow._tcp"
+
+// ssdpMulticastAddr is the well-known SSDP multicast group/port.
+const ssdpMulticastAddr = "239.255.255.250:1900"
+
+// ssdpSearchTarget is what ipshow answers to in M-SEARCH requests,
+// alongside the generic "ssdp:all".
+const ssdpSearchTarget = "urn:ipshow:service:addresses:1"
+
+// addressJSON is the wire format for /addresses.
+type addressJSON struct {
+	Iface     string `json:"iface"`
+	Address   string `json:"address"`
+	Family    string `json:"family"`
+	Scope     string `json:"scope"`
+	IsPrivate bool   `json:"is_private"`
+	Source    string `json:"source"`
+}
+
+// toAddressJSON converts the internal discoveredAddress slice to the
+// JSON wire format.
+func toAddressJSON(addresses []discoveredAddress) []addressJSON {
+	out := make([]addressJSON, len(addresses))
+	for i := range addresses {
+		out[i] = addressJSON{
+			Iface:     addresses[i].iface,
+			Address:   addresses[i].value,
+			Family:    addresses[i].family,
+			Scope:     addresses[i].scope.String(),
+			IsPrivate: addresses[i].scope != scopePublic,
+			Source:    addresses[i].source,
+		}
+	}
+	return out
+}
+
+// serve runs the HTTP API, mDNS announcer and SSDP responder described
+// by --serve. addresses is a single snapshot taken before serving
+// starts; it blocks until the HTTP server stops.
+func serve(listenAddr string, addresses []discoveredAddress) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", handleHealth)
+	mux.HandleFunc("/addresses", handleAddresses(addresses))
+	mux.HandleFunc("/addresses.txt", handleAddressesText(addresses))
+
+	port, err := listenPort(listenAddr)
+	if err != nil {
+		return err
+	}
+
+	if err := announceMDNS(port); err != nil {
+		// mDNS is a nice-to-have; failing to announce shouldn't stop the
+		// HTTP server from serving.
+		log.Print(err.Error())
+	}
+
+	go func() {
+		if err := serveSSDP(port, primaryAddress(addresses)); err != nil {
+			log.Print(err.Error())
+		}
+	}()
+
+	log.Printf("Serving addresses on %s", listenAddr)
+	return http.ListenAndServe(listenAddr, mux)
+}
+
+// listenPort extracts the numeric port ipshow is told to listen on, so
+// it can be reused for the mDNS and SSDP announcements.
+func listenPort(listenAddr string) (int, error) {
+	_, portStr, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return 0, err
+	}
+	var port int
+	if _, err := fmt.Sscanf(portStr, "%d", &port); err != nil {
+		return 0, fmt.Errorf("invalid --serve port '%s': %s", portStr, err.Error())
+	}
+	return port, nil
+}
+
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, "ok")
+}
+
+func handleAddresses(addresses []discoveredAddress) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(toAddressJSON(addresses)); err != nil {
+			log.Print(err.Error())
+		}
+	}
+}
+
+func handleAddressesText(addresses []discoveredAddress) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		values := make([]string, len(addresses))
+		for i := range addresses {
+			values[i] = addresses[i].value
+		}
+		fmt.Fprint(w, strings.Join(values, "\n"))
+	}
+}
+
+// announceMDNS publishes an _ipshow._tcp record for the local host so it
+// can be discovered as "ipshow.local" without already knowing its
+// address.
+func announceMDNS(port int) error {
+	host, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	service, err := mdns.NewMDNSService(host, mdnsServiceName, "", "", port, nil, []string{"ipshow"})
+	if err != nil {
+		return err
+	}
+
+	_, err = mdns.NewServer(&mdns.Config{Zone: service})
+	return err
+}
+
+// primaryAddress picks the address SSDP/mDNS responses should point
+// clients at: the first interface-bound entry in addresses, which
+// sortAddresses has already ordered best-first (IPv4 before IPv6, public
+// before private). Externally-resolved addresses (source != "interface",
+// e.g. a STUN/HTTPS/DNS-discovered public IP) are skipped even if they
+// sort ahead of everything else, since this host isn't actually bound to
+// them and LAN clients following SSDP/mDNS couldn't reach them there.
+func primaryAddress(addresses []discoveredAddress) string {
+	for _, a := range addresses {
+		if a.source == "interface" {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// hostForURL formats hostAddr the way it has to appear in an http://
+// URL: IPv6 literals need square brackets, and a link-local zone index
+// (the "%eth0" in "fe80::1%eth0") needs its '%' percent-encoded per
+// RFC 6874, since a raw '%' in a URL is otherwise just an escape
+// sequence.
+func hostForURL(hostAddr string) string {
+	if !strings.Contains(hostAddr, ":") {
+		return hostAddr
+	}
+
+	if zoneIdx := strings.IndexByte(hostAddr, '%'); zoneIdx != -1 {
+		return "[" + hostAddr[:zoneIdx] + "%25" + hostAddr[zoneIdx+1:] + "]"
+	}
+
+	return "[" + hostAddr + "]"
+}
+
+// serveSSDP answers SSDP M-SEARCH requests on the LAN, pointing back at
+// this host's /addresses endpoint via hostAddr. It blocks until the
+// multicast socket errors out.
+func serveSSDP(port int, hostAddr string) error {
+	groupAddr, err := net.ResolveUDPAddr("udp4", ssdpMulticastAddr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 1500)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return err
+		}
+
+		request := string(buf[:n])
+		if !strings.HasPrefix(request, "M-SEARCH") {
+			continue
+		}
+		if !strings.Contains(request, "ssdp:all") && !strings.Contains(request, ssdpSearchTarget) {
+			continue
+		}
+
+		response := ssdpResponse(hostAddr, port)
+		if _, err := conn.WriteToUDP([]byte(response), src); err != nil {
+			log.Print(err.Error())
+		}
+	}
+}
+
+// ssdpResponse builds the unicast reply to an M-SEARCH request,
+// pointing the requester at our /addresses endpoint.
+func ssdpResponse(hostAddr string, port int) string {
+	location := fmt.Sprintf("http://%s:%d/addresses", hostForURL(hostAddr), port)
+	return "HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age=120\r\n" +
+		"ST: " + ssdpSearchTarget + "\r\n" +
+		"USN: ipshow::" + ssdpSearchTarget + "\r\n" +
+		"LOCATION: " + location + "\r\n" +
+		"SERVER: ipshow\r\n" +
+		"\r\n"
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestPrimaryAddress(t *testing.T) {
+	t.Run("prefers interface address over externally-resolved public IP", func(t *testing.T) {
+		addresses := []discoveredAddress{
+			{iface: "eth0", value: "192.168.1.50", family: "ipv4", scope: scopePrivate, source: "interface"},
+			{value: "203.0.113.9", family: "ipv4", scope: scopePublic, source: "stun"},
+		}
+		sortAddresses(addresses)
+
+		if got := primaryAddress(addresses); got != "192.168.1.50" {
+			t.Fatalf("got %q, want the interface address, not the externally-resolved one", got)
+		}
+	})
+
+	t.Run("no interface addresses", func(t *testing.T) {
+		addresses := []discoveredAddress{
+			{value: "203.0.113.9", family: "ipv4", scope: scopePublic, source: "stun"},
+		}
+		if got := primaryAddress(addresses); got != "" {
+			t.Fatalf("got %q, want empty string when nothing is interface-bound", got)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if got := primaryAddress(nil); got != "" {
+			t.Fatalf("got %q, want empty string", got)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// WatchEventType distinguishes the kinds of changes a Watcher can report.
+type WatchEventType int
+
+const (
+	AddressAdded WatchEventType = iota
+	AddressRemoved
+	InterfaceStateChanged
+)
+
+func (t WatchEventType) String() string {
+	switch t {
+	case AddressAdded:
+		return "address-added"
+	case AddressRemoved:
+		return "address-removed"
+	case InterfaceStateChanged:
+		return "interface-state-changed"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent is one change reported by a Watcher. Address is populated
+// for AddressAdded/AddressRemoved; for InterfaceStateChanged only Iface
+// is meaningful.
+type WatchEvent struct {
+	Type    WatchEventType
+	Address discoveredAddress
+	Iface   string
+}
+
+// Watcher reports interface/address changes as they happen, so callers
+// don't have to keep polling net.Interfaces() themselves.
+type Watcher interface {
+	// Events returns the channel new WatchEvents are delivered on. It is
+	// closed once the watcher is stopped.
+	Events() <-chan WatchEvent
+	// Close stops the watcher and releases any OS resources it holds.
+	Close() error
+}
+
+// defaultPollInterval is used by the polling fallback when the caller
+// doesn't specify one.
+const defaultPollInterval = 5 * time.Second
+
+// NewWatcher returns the best available Watcher for the current
+// platform: a native, event-driven one where we have one (see
+// newNativeWatcher in the platform-specific files), falling back to
+// polling net.Interfaces() every pollInterval otherwise.
+func NewWatcher(filter *InterfaceFilter, pollInterval time.Duration) Watcher {
+	if w, err := newNativeWatcher(filter); err == nil {
+		log.Print("Using native route-change notifications for --watch.")
+		return w
+	} else {
+		log.Printf("Native watcher unavailable (%s), falling back to polling.", err.Error())
+	}
+
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	return newPollingWatcher(filter, pollInterval)
+}
+
+// runWatch re-displays addresses via notifier every time Watcher reports
+// a change, so a laptop roaming between networks keeps its shown
+// addresses current. Events are drained in batches: several interfaces
+// can change within the same moment (e.g. a DHCP renewal touching two
+// NICs at once), and each should produce one re-display, not one per
+// individual event.
+func runWatch(filter *InterfaceFilter, notifier Notifier, pollInterval time.Duration) {
+	w := NewWatcher(filter, pollInterval)
+	defer w.Close()
+
+	log.Print("Watching for interface/address changes...")
+	for event := range w.Events() {
+		log.Printf("Watch event: %s (%s)", event.Type.String(), event.Iface)
+
+	drain:
+		for {
+			select {
+			case event, ok := <-w.Events():
+				if !ok {
+					break drain
+				}
+				log.Printf("Watch event: %s (%s)", event.Type.String(), event.Iface)
+			default:
+				break drain
+			}
+		}
+
+		current, errors := getIps(filter)
+		if len(errors) > 0 {
+			log.Print(errors)
+		}
+		showIps(addressValues(current), notifier)
+	}
+}
+
+// pollingWatcher diffs successive getIps() snapshots on a timer. It's
+// the universal fallback: it works anywhere net.Interfaces() does, at
+// the cost of a detection delay of up to pollInterval.
+type pollingWatcher struct {
+	events chan WatchEvent
+	stop   chan struct{}
+}
+
+func newPollingWatcher(filter *InterfaceFilter, pollInterval time.Duration) *pollingWatcher {
+	w := &pollingWatcher{
+		events: make(chan WatchEvent),
+		stop:   make(chan struct{}),
+	}
+
+	go w.run(filter, pollInterval)
+
+	return w
+}
+
+func (w *pollingWatcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+func (w *pollingWatcher) Close() error {
+	close(w.stop)
+	return nil
+}
+
+func (w *pollingWatcher) run(filter *InterfaceFilter, pollInterval time.Duration) {
+	defer close(w.events)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	previous, _ := getIps(filter)
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			current, errors := getIps(filter)
+			if len(errors) > 0 {
+				log.Print(errors)
+			}
+
+			for _, event := range diffAddresses(previous, current) {
+				select {
+				case w.events <- event:
+				case <-w.stop:
+					return
+				}
+			}
+
+			previous = current
+		}
+	}
+}
+
+// diffAddresses compares two getIps() snapshots and returns the
+// AddressAdded/AddressRemoved events that turn previous into current.
+func diffAddresses(previous, current []discoveredAddress) []WatchEvent {
+	key := func(a discoveredAddress) string {
+		return a.iface + "|" + a.family + "|" + a.value
+	}
+
+	previousSet := make(map[string]discoveredAddress, len(previous))
+	for _, a := range previous {
+		previousSet[key(a)] = a
+	}
+	currentSet := make(map[string]discoveredAddress, len(current))
+	for _, a := range current {
+		currentSet[key(a)] = a
+	}
+
+	var events []WatchEvent
+	for k, a := range currentSet {
+		if _, ok := previousSet[k]; !ok {
+			events = append(events, WatchEvent{Type: AddressAdded, Address: a, Iface: a.iface})
+		}
+	}
+	for k, a := range previousSet {
+		if _, ok := currentSet[k]; !ok {
+			events = append(events, WatchEvent{Type: AddressRemoved, Address: a, Iface: a.iface})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,89 @@
+//go:build darwin
+
+package main
+
+import (
+	"log"
+	"syscall"
+
+	"golang.org/x/net/route"
+)
+
+// routeSocketWatcher reads from an AF_ROUTE socket, which is how
+// BSD-derived kernels (including macOS) announce interface/address
+// changes. Each readable message means "something changed"; we don't
+// bother decoding which interface, we just re-run getIps() and diff.
+type routeSocketWatcher struct {
+	fd     int
+	events chan WatchEvent
+	done   chan struct{}
+}
+
+// newNativeWatcher opens a routing socket and watches it for link/address
+// change messages.
+func newNativeWatcher(filter *InterfaceFilter) (Watcher, error) {
+	fd, err := syscall.Socket(syscall.AF_ROUTE, syscall.SOCK_RAW, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &routeSocketWatcher{
+		fd:     fd,
+		events: make(chan WatchEvent),
+		done:   make(chan struct{}),
+	}
+
+	go w.run(filter)
+
+	return w, nil
+}
+
+func (w *routeSocketWatcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+func (w *routeSocketWatcher) Close() error {
+	close(w.done)
+	return syscall.Close(w.fd)
+}
+
+func (w *routeSocketWatcher) run(filter *InterfaceFilter) {
+	defer close(w.events)
+
+	previous, _ := getIps(filter)
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := syscall.Read(w.fd, buf)
+		if err != nil {
+			select {
+			case <-w.done:
+			default:
+				log.Print(err.Error())
+			}
+			return
+		}
+
+		// We only care that something changed, not the specifics of the
+		// RTM_* message, so a parse failure just means "try again on the
+		// next message" rather than a fatal error.
+		if _, err := route.ParseRIB(route.RIBTypeRoute, buf[:n]); err != nil {
+			continue
+		}
+
+		current, errors := getIps(filter)
+		if len(errors) > 0 {
+			log.Print(errors)
+		}
+
+		for _, event := range diffAddresses(previous, current) {
+			select {
+			case w.events <- event:
+			case <-w.done:
+				return
+			}
+		}
+
+		previous = current
+	}
+}
@@ -0,0 +1,129 @@
+//go:build linux
+
+package main
+
+import (
+	"log"
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// netlinkWatcher subscribes to RTNETLINK link/address change
+// notifications and turns them into WatchEvents.
+type netlinkWatcher struct {
+	events   chan WatchEvent
+	done     chan struct{}
+	linkDone chan struct{}
+	addrDone chan struct{}
+}
+
+// newNativeWatcher subscribes to RTMGRP_LINK, RTMGRP_IPV4_IFADDR and
+// RTMGRP_IPV6_IFADDR via netlink, which is the event-driven way to learn
+// about interface/address changes on Linux.
+func newNativeWatcher(filter *InterfaceFilter) (Watcher, error) {
+	linkUpdates := make(chan netlink.LinkUpdate)
+	linkDone := make(chan struct{})
+	if err := netlink.LinkSubscribe(linkUpdates, linkDone); err != nil {
+		return nil, err
+	}
+
+	addrUpdates := make(chan netlink.AddrUpdate)
+	addrDone := make(chan struct{})
+	if err := netlink.AddrSubscribe(addrUpdates, addrDone); err != nil {
+		close(linkDone)
+		return nil, err
+	}
+
+	w := &netlinkWatcher{
+		events:   make(chan WatchEvent),
+		done:     make(chan struct{}),
+		linkDone: linkDone,
+		addrDone: addrDone,
+	}
+
+	go w.run(filter, linkUpdates, addrUpdates)
+
+	return w, nil
+}
+
+func (w *netlinkWatcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+func (w *netlinkWatcher) Close() error {
+	close(w.done)
+	close(w.linkDone)
+	close(w.addrDone)
+	return nil
+}
+
+func (w *netlinkWatcher) run(filter *InterfaceFilter, linkUpdates chan netlink.LinkUpdate, addrUpdates chan netlink.AddrUpdate) {
+	defer close(w.events)
+
+	for {
+		select {
+		case <-w.done:
+			return
+
+		case update, ok := <-linkUpdates:
+			if !ok {
+				return
+			}
+			w.emit(WatchEvent{Type: InterfaceStateChanged, Iface: update.Link.Attrs().Name})
+
+		case update, ok := <-addrUpdates:
+			if !ok {
+				return
+			}
+			iface, err := netlink.LinkByIndex(update.LinkIndex)
+			if err != nil {
+				log.Print(err.Error())
+				continue
+			}
+			attrs := iface.Attrs()
+			if !filter.Allowed(net.Interface{
+				Index:        attrs.Index,
+				MTU:          attrs.MTU,
+				Name:         attrs.Name,
+				HardwareAddr: attrs.HardwareAddr,
+				Flags:        attrs.Flags,
+			}) {
+				continue
+			}
+
+			eventType := AddressAdded
+			if !update.NewAddr {
+				eventType = AddressRemoved
+			}
+
+			address := update.LinkAddress.IP
+			family := "ipv4"
+			scope := scopePrivate
+			if address.To4() == nil {
+				family = "ipv6"
+				_, scope = checksForIPv6(address)
+			} else {
+				_, scope = checksForIPv4(address)
+			}
+
+			w.emit(WatchEvent{
+				Type:  eventType,
+				Iface: iface.Attrs().Name,
+				Address: discoveredAddress{
+					iface:  iface.Attrs().Name,
+					value:  address.String(),
+					family: family,
+					scope:  scope,
+				},
+			})
+		}
+	}
+}
+
+func (w *netlinkWatcher) emit(event WatchEvent) {
+	select {
+	case w.events <- event:
+	case <-w.done:
+	}
+}
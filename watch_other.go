@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "errors"
+
+// newNativeWatcher has no implementation on the remaining BSDs yet
+// (they'd want the same route-socket approach as watch_darwin.go), so
+// NewWatcher falls back to pollingWatcher here.
+func newNativeWatcher(filter *InterfaceFilter) (Watcher, error) {
+	return nil, errors.New("native interface watching isn't implemented on this platform")
+}
@@ -0,0 +1,85 @@
+//go:build windows
+
+package main
+
+import (
+	"log"
+	"syscall"
+)
+
+// iphlpapi's NotifyAddrChange(NULL, NULL) blocks the calling thread
+// until the IPv4 address table changes, then returns; that's the
+// classic Win32 way to learn about address changes without polling.
+var (
+	iphlpapi             = syscall.NewLazyDLL("iphlpapi.dll")
+	procNotifyAddrChange = iphlpapi.NewProc("NotifyAddrChange")
+)
+
+// win32AddrWatcher wraps repeated calls to NotifyAddrChange, re-running
+// getIps() and diffing every time it returns.
+type win32AddrWatcher struct {
+	events chan WatchEvent
+	done   chan struct{}
+}
+
+func newNativeWatcher(filter *InterfaceFilter) (Watcher, error) {
+	w := &win32AddrWatcher{
+		events: make(chan WatchEvent),
+		done:   make(chan struct{}),
+	}
+
+	go w.run(filter)
+
+	return w, nil
+}
+
+func (w *win32AddrWatcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+func (w *win32AddrWatcher) Close() error {
+	close(w.done)
+	return nil
+}
+
+func (w *win32AddrWatcher) run(filter *InterfaceFilter) {
+	defer close(w.events)
+
+	previous, _ := getIps(filter)
+
+	for {
+		// Passing NULL/NULL for the overlapped handle and event makes
+		// this call synchronous: it simply blocks until the address
+		// table changes.
+		ret, _, _ := procNotifyAddrChange.Call(0, 0)
+		if ret != 0 {
+			select {
+			case <-w.done:
+			default:
+				log.Print("NotifyAddrChange failed")
+			}
+			return
+		}
+
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+
+		current, errors := getIps(filter)
+		if len(errors) > 0 {
+			log.Print(errors)
+		}
+
+		for _, event := range diffAddresses(previous, current) {
+			select {
+			case w.events <- event:
+			case <-w.done:
+				return
+			}
+		}
+
+		previous = current
+	}
+}